@@ -17,7 +17,14 @@ limitations under the License.
 package opentelemetry
 
 import (
+	"fmt"
+	"maps"
+	"net/url"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 
 	networking "k8s.io/api/networking/v1"
 	"k8s.io/klog/v2"
@@ -28,14 +35,161 @@ import (
 )
 
 const (
-	enableOpenTelemetryAnnotation = "enable-opentelemetry"
-	otelTrustSpanAnnotation       = "opentelemetry-trust-incoming-span"
-	otelOperationNameAnnotation   = "opentelemetry-operation-name"
-	otelPropagationTypeAnnotation = "opentelemetry-propagation-type"
+	enableOpenTelemetryAnnotation    = "enable-opentelemetry"
+	otelTrustSpanAnnotation          = "opentelemetry-trust-incoming-span"
+	otelOperationNameAnnotation      = "opentelemetry-operation-name"
+	otelPropagationTypeAnnotation    = "opentelemetry-propagation-type"
+	otelSamplerAnnotation            = "opentelemetry-sampler"
+	otelSamplerRatioAnnotation       = "opentelemetry-sampler-ratio"
+	otelSamplerParentBasedAnnotation = "opentelemetry-sampler-parent-based"
+	otelSpanAttributePrefix          = "opentelemetry-span-attribute-"
+	otelResourceAttributePrefix      = "opentelemetry-resource-attribute-"
+	otelOTLPEndpointAnnotation       = "opentelemetry-otlp-endpoint"
+	otelOTLPHeadersAnnotation        = "opentelemetry-otlp-headers"
+	otelServiceNameAnnotation        = "opentelemetry-service-name"
+	otelLogsEnabledAnnotation        = "opentelemetry-logs-enabled"
+	otelLogsSeverityAnnotation       = "opentelemetry-logs-severity"
+	otelLogsIncludeHeadersAnnotation = "opentelemetry-logs-include-headers"
+
+	// maxOtelAttributes caps how many span attributes, and separately how many
+	// resource attributes, a single ingress may add, so a misconfigured object
+	// can't blow up the span size.
+	maxOtelAttributes = 32
+	// maxOtelAttributeValueLength caps the length of an individual attribute value.
+	maxOtelAttributeValueLength = 255
 )
 
+// AllowedOTLPEndpointHosts restricts which hosts the opentelemetry-otlp-endpoint
+// annotation may target. An ingress requesting a host outside this list (or
+// a subdomain of one of these hosts) has its OTLP endpoint override ignored,
+// falling back to the controller-wide collector. This package never assigns
+// it: it stays empty unless the code starting the controller sets it, and an
+// empty list disables the check. This guards against SSRF-style misuse where
+// a hostile ingress author points span/log export at an internal service.
+var AllowedOTLPEndpointHosts []string
+
+var regexServiceName = regexp.MustCompile(`^[A-Za-z0-9_\-.]*$`)
+
+// validLogSeverities are the OpenTelemetry Logs data model severity names.
+var validLogSeverities = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// regexHeaderList matches a comma-separated list of HTTP header names.
+var regexHeaderList = regexp.MustCompile(`^[A-Za-z0-9_\-]+(,[A-Za-z0-9_\-]+)*$`)
+
+// regexOTLPHeaders matches a comma-separated list of key=value pairs, e.g.
+// "Authorization=Bearer xyz,X-Tenant=acme". The value may itself contain "="
+// (e.g. base64-padded bearer tokens); only the first "=" in each pair
+// separates the key, matching how parseHeaderList splits it.
+var regexOTLPHeaders = regexp.MustCompile(`^[A-Za-z0-9_\-]+=[^,]+(,[A-Za-z0-9_\-]+=[^,]+)*$`)
+
+// validPropagationTypes are the propagators that can be combined, in order,
+// into the composite propagator nginx registers for a location. They mirror
+// the propagators supported by the OpenTelemetry nginx module.
+var validPropagationTypes = []string{"w3c", "tracecontext", "baggage", "b3", "b3multi", "jaeger", "ottrace"}
+
+// validSamplers are the sampler names accepted by the OpenTelemetry nginx module.
+var validSamplers = []string{"always_on", "always_off", "traceidratio", "parentbased_always_on", "parentbased_traceidratio"}
+
 var regexOperationName = regexp.MustCompile(`^[A-Za-z0-9_\-]*$`)
 
+// regexAttributeKey follows the OpenTelemetry attribute naming convention:
+// lowercase, dot-namespaced segments (e.g. "tenant.id").
+var regexAttributeKey = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)*$`)
+
+// validateSamplerRatio ensures the sampler ratio is a float in the [0,1] range.
+func validateSamplerRatio(value string) error {
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return errors.NewInvalidAnnotationContent(otelSamplerRatioAnnotation, value)
+	}
+
+	if ratio < 0 || ratio > 1 {
+		return errors.NewInvalidAnnotationContent(otelSamplerRatioAnnotation, fmt.Sprintf("%v: must be between 0 and 1", value))
+	}
+
+	return nil
+}
+
+// validatePropagationType ensures the annotation value is a non-empty,
+// comma-separated list whose entries are all in validPropagationTypes.
+func validatePropagationType(value string) error {
+	parts := splitAnnotationList(value)
+	if len(parts) == 0 {
+		return errors.NewInvalidAnnotationContent(otelPropagationTypeAnnotation, value)
+	}
+
+	for _, part := range parts {
+		if !slices.Contains(validPropagationTypes, part) {
+			return errors.NewInvalidAnnotationContent(otelPropagationTypeAnnotation, value)
+		}
+	}
+
+	return nil
+}
+
+// validateOTLPEndpoint ensures the annotation value is an absolute http(s) URL.
+func validateOTLPEndpoint(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.NewInvalidAnnotationContent(otelOTLPEndpointAnnotation, value)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.NewInvalidAnnotationContent(otelOTLPEndpointAnnotation, value)
+	}
+
+	return nil
+}
+
+// parseAttributeAnnotations collects the `<prefix><key>` annotations into a
+// key/value map, dropping entries with an invalid OTel attribute key and
+// truncating values that exceed maxOtelAttributeValueLength. Values are kept
+// verbatim so callers can interpolate nginx variables (e.g. $request_id) in
+// the template. Candidate keys are sorted before the maxOtelAttributes cap is
+// applied so that which entries survive is stable across repeated calls on
+// the same, unchanged Ingress - map iteration order is not - which in turn
+// keeps Config.Equal from flip-flopping and triggering needless reloads.
+func parseAttributeAnnotations(ing *networking.Ingress, prefix string) map[string]string {
+	fullPrefix := fmt.Sprintf("%s/%s", parser.AnnotationsPrefix, prefix)
+
+	rawAnnotations := ing.GetAnnotations()
+	annotationNames := make([]string, 0, len(rawAnnotations))
+	for annotation := range rawAnnotations {
+		if strings.HasPrefix(annotation, fullPrefix) {
+			annotationNames = append(annotationNames, annotation)
+		}
+	}
+	sort.Strings(annotationNames)
+
+	var attributes map[string]string
+	for _, annotation := range annotationNames {
+		key := strings.TrimPrefix(annotation, fullPrefix)
+
+		if !regexAttributeKey.MatchString(key) {
+			klog.Warningf("annotation %s has an invalid attribute key %q, skipping", annotation, key)
+			continue
+		}
+
+		if attributes == nil {
+			attributes = make(map[string]string)
+		}
+
+		if len(attributes) >= maxOtelAttributes {
+			klog.Warningf("ingress %s/%s declares more than %d OpenTelemetry attributes, ignoring %s", ing.Namespace, ing.Name, maxOtelAttributes, annotation)
+			continue
+		}
+
+		value := rawAnnotations[annotation]
+		if len(value) > maxOtelAttributeValueLength {
+			value = value[:maxOtelAttributeValueLength]
+		}
+
+		attributes[key] = value
+	}
+
+	return attributes
+}
+
 var otelAnnotations = parser.Annotation{
 	Group: "opentelemetry",
 	Annotations: parser.AnnotationFields{
@@ -59,10 +213,65 @@ var otelAnnotations = parser.Annotation{
 			Documentation: `This annotation defines what operation name should be added to the span`,
 		},
 		otelPropagationTypeAnnotation: {
-			Validator:     parser.ValidateOptions([]string{"w3c", "b3"}, false, true),
+			Validator: validatePropagationType,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation defines what propagation type(s) should be used for the span. It accepts a
+			comma-separated list (e.g. "tracecontext,baggage,b3") which is run as a composite propagator, in the given order`,
+		},
+		otelSamplerAnnotation: {
+			Validator:     parser.ValidateOptions(validSamplers, false, true),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the sampler to be used by the OpenTelemetry module for this location, instead of relying on the collector-side sampler`,
+		},
+		otelSamplerRatioAnnotation: {
+			Validator:     validateSamplerRatio,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation sets the sampling ratio used by the traceidratio/parentbased_traceidratio samplers, as a float between 0 and 1`,
+		},
+		otelSamplerParentBasedAnnotation: {
+			Validator:     parser.ValidateBool,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation wraps the chosen sampler in a ParentBased decorator, honoring opentelemetry-trust-incoming-span`,
+		},
+		otelOTLPEndpointAnnotation: {
+			Validator:     validateOTLPEndpoint,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskMedium,
+			Documentation: `This annotation sets a per-ingress OTLP collector endpoint, overriding the controller-wide collector for this location`,
+		},
+		otelOTLPHeadersAnnotation: {
+			Validator:     parser.ValidateRegex(regexOTLPHeaders, true),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskHigh,
+			Documentation: `This annotation sets a comma-separated list of "key=value" headers (e.g. auth tokens) sent with every OTLP export to opentelemetry-otlp-endpoint`,
+		},
+		otelServiceNameAnnotation: {
+			Validator:     parser.ValidateRegex(regexServiceName, true),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation overrides the service.name resource attribute reported for spans from this location`,
+		},
+		otelLogsEnabledAnnotation: {
+			Validator:     parser.ValidateBool,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines if access log records for this location should also be exported via OTLP, correlated with the current trace/span id`,
+		},
+		otelLogsSeverityAnnotation: {
+			Validator:     parser.ValidateOptions(validLogSeverities, false, true),
 			Scope:         parser.AnnotationScopeLocation,
 			Risk:          parser.AnnotationRiskLow,
-			Documentation: `This annotation defines what propagation type should be used for the span`,
+			Documentation: `This annotation sets the severity recorded on the exported log records for this location`,
+		},
+		otelLogsIncludeHeadersAnnotation: {
+			Validator:     parser.ValidateRegex(regexHeaderList, true),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskMedium,
+			Documentation: `This annotation sets a comma-separated list of request header names to copy onto the exported log records`,
 		},
 	},
 }
@@ -74,12 +283,28 @@ type opentelemetry struct {
 
 // Config contains the configuration to be used in the Ingress
 type Config struct {
-	Enabled         bool   `json:"enabled"`
-	Set             bool   `json:"set"`
-	TrustEnabled    bool   `json:"trust-enabled"`
-	TrustSet        bool   `json:"trust-set"`
-	OperationName   string `json:"operation-name"`
-	PropagationType string `json:"propagation-type"`
+	Enabled               bool              `json:"enabled"`
+	Set                   bool              `json:"set"`
+	TrustEnabled          bool              `json:"trust-enabled"`
+	TrustSet              bool              `json:"trust-set"`
+	OperationName         string            `json:"operation-name"`
+	PropagationType       []string          `json:"propagation-type"`
+	Sampler               string            `json:"sampler"`
+	SamplerSet            bool              `json:"sampler-set"`
+	SamplerRatio          float64           `json:"sampler-ratio"`
+	SamplerRatioSet       bool              `json:"sampler-ratio-set"`
+	SamplerParentBased    bool              `json:"sampler-parent-based"`
+	SamplerParentBasedSet bool              `json:"sampler-parent-based-set"`
+	SpanAttributes        map[string]string `json:"span-attributes"`
+	ResourceAttributes    map[string]string `json:"resource-attributes"`
+	OTLPEndpoint          string            `json:"otlp-endpoint"`
+	OTLPEndpointSet       bool              `json:"otlp-endpoint-set"`
+	OTLPHeaders           map[string]string `json:"otlp-headers"`
+	ServiceName           string            `json:"service-name"`
+	LogsEnabled           bool              `json:"logs-enabled"`
+	LogsEnabledSet        bool              `json:"logs-enabled-set"`
+	LogsSeverity          string            `json:"logs-severity"`
+	LogsIncludeHeaders    []string          `json:"logs-include-headers"`
 }
 
 // Equal tests for equality between two Config types
@@ -104,10 +329,86 @@ func (bd1 *Config) Equal(bd2 *Config) bool {
 		return false
 	}
 
-	if bd1.PropagationType != bd2.PropagationType {
+	if len(bd1.PropagationType) != len(bd2.PropagationType) {
+		return false
+	}
+
+	for i, propagationType := range bd1.PropagationType {
+		if propagationType != bd2.PropagationType[i] {
+			return false
+		}
+	}
+
+	if bd1.SamplerSet != bd2.SamplerSet {
+		return false
+	}
+
+	if bd1.Sampler != bd2.Sampler {
+		return false
+	}
+
+	if bd1.SamplerRatioSet != bd2.SamplerRatioSet {
+		return false
+	}
+
+	if bd1.SamplerRatio != bd2.SamplerRatio {
+		return false
+	}
+
+	if bd1.SamplerParentBasedSet != bd2.SamplerParentBasedSet {
+		return false
+	}
+
+	if bd1.SamplerParentBased != bd2.SamplerParentBased {
+		return false
+	}
+
+	if !maps.Equal(bd1.SpanAttributes, bd2.SpanAttributes) {
+		return false
+	}
+
+	if !maps.Equal(bd1.ResourceAttributes, bd2.ResourceAttributes) {
+		return false
+	}
+
+	if bd1.OTLPEndpointSet != bd2.OTLPEndpointSet {
+		return false
+	}
+
+	if bd1.OTLPEndpoint != bd2.OTLPEndpoint {
+		return false
+	}
+
+	if !maps.Equal(bd1.OTLPHeaders, bd2.OTLPHeaders) {
+		return false
+	}
+
+	if bd1.ServiceName != bd2.ServiceName {
+		return false
+	}
+
+	if bd1.LogsEnabledSet != bd2.LogsEnabledSet {
+		return false
+	}
+
+	if bd1.LogsEnabled != bd2.LogsEnabled {
+		return false
+	}
+
+	if bd1.LogsSeverity != bd2.LogsSeverity {
+		return false
+	}
+
+	if len(bd1.LogsIncludeHeaders) != len(bd2.LogsIncludeHeaders) {
 		return false
 	}
 
+	for i, header := range bd1.LogsIncludeHeaders {
+		if header != bd2.LogsIncludeHeaders[i] {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -152,17 +453,162 @@ func (c opentelemetry) Parse(ing *networking.Ingress) (interface{}, error) {
 		config.OperationName = ""
 	}
 
-	config.PropagationType, err = parser.GetStringAnnotation(otelPropagationTypeAnnotation, ing, c.annotationConfig.Annotations)
+	propagationType, err := parser.GetStringAnnotation(otelPropagationTypeAnnotation, ing, c.annotationConfig.Annotations)
 	if err != nil {
 		if errors.IsInvalidContent(err) {
 			klog.Warningf("annotation %s contains invalid directive, defaulting", otelPropagationTypeAnnotation)
 		}
-		config.PropagationType = ""
+		config.PropagationType = nil
+	} else {
+		config.PropagationType = splitAnnotationList(propagationType)
+	}
+
+	config.SamplerSet = true
+	config.Sampler, err = parser.GetStringAnnotation(otelSamplerAnnotation, ing, c.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsInvalidContent(err) {
+			klog.Warningf("annotation %s contains invalid directive, defaulting", otelSamplerAnnotation)
+		}
+		config.Sampler = ""
+		config.SamplerSet = false
+	}
+
+	config.SamplerRatioSet = true
+	config.SamplerRatio, err = parser.GetFloatAnnotation(otelSamplerRatioAnnotation, ing, c.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsInvalidContent(err) {
+			klog.Warningf("annotation %s contains invalid directive, defaulting", otelSamplerRatioAnnotation)
+		}
+		config.SamplerRatio = 0
+		config.SamplerRatioSet = false
+	}
+
+	config.SamplerParentBasedSet = true
+	config.SamplerParentBased, err = parser.GetBoolAnnotation(otelSamplerParentBasedAnnotation, ing, c.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsInvalidContent(err) {
+			klog.Warningf("annotation %s contains invalid directive, defaulting to false", otelSamplerParentBasedAnnotation)
+		}
+		config.SamplerParentBased = false
+		config.SamplerParentBasedSet = false
+	}
+
+	config.SpanAttributes = parseAttributeAnnotations(ing, otelSpanAttributePrefix)
+	config.ResourceAttributes = parseAttributeAnnotations(ing, otelResourceAttributePrefix)
+
+	config.OTLPEndpointSet = true
+	config.OTLPEndpoint, err = parser.GetStringAnnotation(otelOTLPEndpointAnnotation, ing, c.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsInvalidContent(err) {
+			klog.Warningf("annotation %s contains invalid directive, defaulting", otelOTLPEndpointAnnotation)
+		}
+		config.OTLPEndpoint = ""
+		config.OTLPEndpointSet = false
+	} else if len(AllowedOTLPEndpointHosts) > 0 {
+		if host, ok := matchesHostAllowlist(config.OTLPEndpoint, AllowedOTLPEndpointHosts); !ok {
+			klog.Warningf("ingress %s/%s requested OTLP endpoint host %q which is not in the allowed list, ignoring %s", ing.Namespace, ing.Name, host, otelOTLPEndpointAnnotation)
+			config.OTLPEndpoint = ""
+			config.OTLPEndpointSet = false
+		}
+	}
+
+	otlpHeaders, err := parser.GetStringAnnotation(otelOTLPHeadersAnnotation, ing, c.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsInvalidContent(err) {
+			klog.Warningf("annotation %s contains invalid directive, defaulting", otelOTLPHeadersAnnotation)
+		}
+		config.OTLPHeaders = nil
+	} else if !strings.HasPrefix(config.OTLPEndpoint, "https://") {
+		klog.Warningf("ingress %s/%s sets %s without an https %s, ignoring headers", ing.Namespace, ing.Name, otelOTLPHeadersAnnotation, otelOTLPEndpointAnnotation)
+		config.OTLPHeaders = nil
+	} else {
+		config.OTLPHeaders = parseHeaderList(otlpHeaders)
+	}
+
+	config.ServiceName, err = parser.GetStringAnnotation(otelServiceNameAnnotation, ing, c.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsInvalidContent(err) {
+			klog.Warningf("annotation %s contains invalid directive, defaulting", otelServiceNameAnnotation)
+		}
+		config.ServiceName = ""
+	}
+
+	config.LogsEnabledSet = true
+	config.LogsEnabled, err = parser.GetBoolAnnotation(otelLogsEnabledAnnotation, ing, c.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsInvalidContent(err) {
+			klog.Warningf("annotation %s contains invalid directive, defaulting to false", otelLogsEnabledAnnotation)
+		}
+		config.LogsEnabled = false
+		config.LogsEnabledSet = false
+	}
+
+	config.LogsSeverity, err = parser.GetStringAnnotation(otelLogsSeverityAnnotation, ing, c.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsInvalidContent(err) {
+			klog.Warningf("annotation %s contains invalid directive, defaulting", otelLogsSeverityAnnotation)
+		}
+		config.LogsSeverity = ""
+	}
+
+	logsIncludeHeaders, err := parser.GetStringAnnotation(otelLogsIncludeHeadersAnnotation, ing, c.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsInvalidContent(err) {
+			klog.Warningf("annotation %s contains invalid directive, defaulting", otelLogsIncludeHeadersAnnotation)
+		}
+		config.LogsIncludeHeaders = nil
+	} else {
+		config.LogsIncludeHeaders = splitAnnotationList(logsIncludeHeaders)
 	}
 
 	return config, nil
 }
 
+// matchesHostAllowlist reports whether the endpoint's host is, or is a
+// subdomain of, one of the allowed hostnames.
+func matchesHostAllowlist(endpoint string, allowlist []string) (string, bool) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint, false
+	}
+
+	for _, allowed := range allowlist {
+		if u.Hostname() == allowed || strings.HasSuffix(u.Hostname(), "."+allowed) {
+			return u.Hostname(), true
+		}
+	}
+
+	return u.Hostname(), false
+}
+
+// parseHeaderList turns a "k=v,k2=v2" annotation value into a map.
+func parseHeaderList(value string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+
+	return headers
+}
+
+// splitAnnotationList splits a comma-separated annotation value into its
+// trimmed, non-empty parts, preserving the order they were declared in.
+func splitAnnotationList(value string) []string {
+	parts := strings.Split(value, ",")
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 func (c opentelemetry) GetDocumentation() parser.AnnotationFields {
 	return c.annotationConfig.Annotations
 }
@@ -0,0 +1,268 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opentelemetry
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+func buildIngressWithAnnotations(annotations map[string]string) *networking.Ingress {
+	return &networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestSplitAnnotationList(t *testing.T) {
+	assert.Equal(t, []string{"tracecontext", "baggage", "b3"}, splitAnnotationList("tracecontext,baggage,b3"))
+	assert.Equal(t, []string{"w3c"}, splitAnnotationList("w3c"))
+	assert.Empty(t, splitAnnotationList(""))
+}
+
+func TestConfigEqualPropagationType(t *testing.T) {
+	base := &Config{PropagationType: []string{"tracecontext", "baggage"}}
+
+	assert.True(t, base.Equal(&Config{PropagationType: []string{"tracecontext", "baggage"}}))
+	assert.False(t, base.Equal(&Config{PropagationType: []string{"baggage", "tracecontext"}}))
+	assert.False(t, base.Equal(&Config{PropagationType: []string{"tracecontext"}}))
+	assert.False(t, base.Equal(&Config{}))
+}
+
+func TestParseAttributeAnnotationsDeterministicUnderCap(t *testing.T) {
+	prefix := otelSpanAttributePrefix
+	annotations := map[string]string{
+		fmt.Sprintf("%s/%stenant.id", parser.AnnotationsPrefix, prefix):    "acme",
+		fmt.Sprintf("%s/%sfeature.flag", parser.AnnotationsPrefix, prefix): "beta",
+	}
+
+	ing := buildIngressWithAnnotations(annotations)
+
+	got := parseAttributeAnnotations(ing, prefix)
+
+	assert.Equal(t, map[string]string{"tenant.id": "acme", "feature.flag": "beta"}, got)
+}
+
+func TestParseAttributeAnnotationsOverCapIsStable(t *testing.T) {
+	prefix := otelSpanAttributePrefix
+	annotations := make(map[string]string, maxOtelAttributes+8)
+	for i := 0; i < maxOtelAttributes+8; i++ {
+		key := fmt.Sprintf("key%02d", i)
+		annotations[fmt.Sprintf("%s/%s%s", parser.AnnotationsPrefix, prefix, key)] = fmt.Sprintf("value%02d", i)
+	}
+
+	ing := buildIngressWithAnnotations(annotations)
+
+	first := parseAttributeAnnotations(ing, prefix)
+	assert.Len(t, first, maxOtelAttributes)
+
+	// Map iteration order is randomized per process, so repeated calls on the
+	// same unchanged Ingress must still return the exact same set of keys.
+	for i := 0; i < 10; i++ {
+		again := parseAttributeAnnotations(ing, prefix)
+		assert.Equal(t, first, again, "parseAttributeAnnotations must be deterministic across repeated calls")
+	}
+
+	// The surviving keys should be the lexicographically first ones.
+	assert.Contains(t, first, "key00")
+	assert.NotContains(t, first, fmt.Sprintf("key%02d", maxOtelAttributes+7))
+}
+
+func TestParseAttributeAnnotationsInvalidKeySkipped(t *testing.T) {
+	prefix := otelSpanAttributePrefix
+	annotations := map[string]string{
+		fmt.Sprintf("%s/%sTenant-ID", parser.AnnotationsPrefix, prefix): "acme",
+		fmt.Sprintf("%s/%stenant.id", parser.AnnotationsPrefix, prefix): "acme",
+	}
+
+	ing := buildIngressWithAnnotations(annotations)
+
+	got := parseAttributeAnnotations(ing, prefix)
+
+	assert.Equal(t, map[string]string{"tenant.id": "acme"}, got)
+}
+
+func TestParseAttributeAnnotationsValueTruncated(t *testing.T) {
+	prefix := otelSpanAttributePrefix
+	longValue := ""
+	for i := 0; i < maxOtelAttributeValueLength+10; i++ {
+		longValue += "a"
+	}
+
+	annotations := map[string]string{
+		fmt.Sprintf("%s/%stenant.id", parser.AnnotationsPrefix, prefix): longValue,
+	}
+
+	ing := buildIngressWithAnnotations(annotations)
+
+	got := parseAttributeAnnotations(ing, prefix)
+
+	assert.Len(t, got["tenant.id"], maxOtelAttributeValueLength)
+}
+
+func TestValidateSamplerRatio(t *testing.T) {
+	assert.NoError(t, validateSamplerRatio("0"))
+	assert.NoError(t, validateSamplerRatio("0.5"))
+	assert.NoError(t, validateSamplerRatio("1"))
+	assert.Error(t, validateSamplerRatio("-0.1"))
+	assert.Error(t, validateSamplerRatio("1.1"))
+	assert.Error(t, validateSamplerRatio("not-a-number"))
+}
+
+func TestConfigEqualSampler(t *testing.T) {
+	base := &Config{
+		SamplerSet:            true,
+		Sampler:               "traceidratio",
+		SamplerRatioSet:       true,
+		SamplerRatio:          0.25,
+		SamplerParentBasedSet: true,
+		SamplerParentBased:    true,
+	}
+
+	assert.True(t, base.Equal(&Config{
+		SamplerSet:            true,
+		Sampler:               "traceidratio",
+		SamplerRatioSet:       true,
+		SamplerRatio:          0.25,
+		SamplerParentBasedSet: true,
+		SamplerParentBased:    true,
+	}))
+
+	other := *base
+	other.SamplerRatio = 0.5
+	assert.False(t, base.Equal(&other))
+
+	other = *base
+	other.SamplerParentBased = false
+	assert.False(t, base.Equal(&other))
+}
+
+func TestConfigEqualLogs(t *testing.T) {
+	base := &Config{
+		LogsEnabledSet:     true,
+		LogsEnabled:        true,
+		LogsSeverity:       "WARN",
+		LogsIncludeHeaders: []string{"X-Request-Id", "User-Agent"},
+	}
+
+	assert.True(t, base.Equal(&Config{
+		LogsEnabledSet:     true,
+		LogsEnabled:        true,
+		LogsSeverity:       "WARN",
+		LogsIncludeHeaders: []string{"X-Request-Id", "User-Agent"},
+	}))
+
+	other := *base
+	other.LogsIncludeHeaders = []string{"User-Agent", "X-Request-Id"}
+	assert.False(t, base.Equal(&other))
+
+	other = *base
+	other.LogsSeverity = "ERROR"
+	assert.False(t, base.Equal(&other))
+}
+
+func TestConfigEqualOTLP(t *testing.T) {
+	base := &Config{
+		OTLPEndpointSet: true,
+		OTLPEndpoint:    "https://otel.example.com:4318",
+		OTLPHeaders:     map[string]string{"Authorization": "Bearer xyz"},
+		ServiceName:     "checkout",
+	}
+
+	assert.True(t, base.Equal(&Config{
+		OTLPEndpointSet: true,
+		OTLPEndpoint:    "https://otel.example.com:4318",
+		OTLPHeaders:     map[string]string{"Authorization": "Bearer xyz"},
+		ServiceName:     "checkout",
+	}))
+
+	other := *base
+	other.OTLPEndpointSet = false
+	assert.False(t, base.Equal(&other))
+
+	other = *base
+	other.ServiceName = "other"
+	assert.False(t, base.Equal(&other))
+}
+
+func TestConfigEqualAttributes(t *testing.T) {
+	base := &Config{
+		SpanAttributes:     map[string]string{"tenant.id": "acme"},
+		ResourceAttributes: map[string]string{"deployment.environment": "prod"},
+	}
+
+	assert.True(t, base.Equal(&Config{
+		SpanAttributes:     map[string]string{"tenant.id": "acme"},
+		ResourceAttributes: map[string]string{"deployment.environment": "prod"},
+	}))
+
+	other := *base
+	other.SpanAttributes = map[string]string{"tenant.id": "other"}
+	assert.False(t, base.Equal(&other))
+
+	other = *base
+	other.ResourceAttributes = nil
+	assert.False(t, base.Equal(&other))
+}
+
+func TestMatchesHostAllowlist(t *testing.T) {
+	testCases := map[string]struct {
+		endpoint  string
+		allowlist []string
+		wantOK    bool
+	}{
+		"exact match":      {"https://otel.example.com/v1/traces", []string{"otel.example.com"}, true},
+		"subdomain match":  {"https://tenant-a.otel.example.com", []string{"otel.example.com"}, true},
+		"no match":         {"https://evil.example.com", []string{"otel.example.com"}, false},
+		"unrelated suffix": {"https://evilotel.example.com", []string{"otel.example.com"}, false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, ok := matchesHostAllowlist(tc.endpoint, tc.allowlist)
+			assert.Equal(t, tc.wantOK, ok)
+		})
+	}
+}
+
+func TestValidateOTLPEndpoint(t *testing.T) {
+	assert.NoError(t, validateOTLPEndpoint("https://otel.example.com:4318"))
+	assert.NoError(t, validateOTLPEndpoint("http://otel.example.com:4318"))
+	assert.Error(t, validateOTLPEndpoint("not-a-url"))
+	assert.Error(t, validateOTLPEndpoint("ftp://otel.example.com"))
+}
+
+func TestParseHeaderList(t *testing.T) {
+	got := parseHeaderList("Authorization=Bearer xyz,X-Tenant=acme")
+	assert.Equal(t, map[string]string{"Authorization": "Bearer xyz", "X-Tenant": "acme"}, got)
+}
+
+func TestRegexOTLPHeadersAllowsEqualsInValue(t *testing.T) {
+	assert.True(t, regexOTLPHeaders.MatchString("Authorization=Bearer xyz=="))
+	assert.True(t, regexOTLPHeaders.MatchString("Authorization=Bearer xyz==,X-Tenant=acme"))
+	assert.False(t, regexOTLPHeaders.MatchString("Authorization"))
+	assert.False(t, regexOTLPHeaders.MatchString("=Bearer xyz"))
+}
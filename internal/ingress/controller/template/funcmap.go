@@ -0,0 +1,28 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import "text/template"
+
+// funcMap holds the helpers that nginx.tmpl can invoke, e.g.
+// `{{ buildOpentelemetry . }}`. This tree does not carry nginx.tmpl or the
+// template-loading code that owns the rest of the template functions, so this
+// map holds only the OpenTelemetry helpers added here and nothing in this
+// tree merges it into a template's function map.
+var funcMap = template.FuncMap{
+	"buildOpentelemetry": buildOpentelemetry,
+}
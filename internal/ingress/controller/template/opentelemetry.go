@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/opentelemetry"
+)
+
+// buildOpentelemetry renders the nginx directives needed to configure the
+// OpenTelemetry module for a location, based on the annotations parsed onto
+// it. It is registered in funcMap (see funcmap.go) for nginx.tmpl to call as
+// `{{ buildOpentelemetry . }}`; this tree does not carry nginx.tmpl itself,
+// so wiring the call site into the template is left to whoever owns it.
+func buildOpentelemetry(input interface{}) string {
+	cfg, ok := input.(*opentelemetry.Config)
+	if !ok || cfg == nil || !cfg.Enabled {
+		return ""
+	}
+
+	var directives []string
+
+	if len(cfg.PropagationType) > 0 {
+		directives = append(directives, fmt.Sprintf("opentelemetry_propagate %s;", strings.Join(cfg.PropagationType, " ")))
+	}
+
+	if cfg.SamplerSet && cfg.Sampler != "" {
+		sampler := cfg.Sampler
+		// The ParentBased decorator makes its sampling decision from the
+		// parent span context on the incoming request, so it only makes
+		// sense to apply it when that incoming span is trusted; otherwise a
+		// client could forge a parent context to force/skip sampling.
+		if cfg.SamplerParentBasedSet && cfg.SamplerParentBased && cfg.TrustEnabled && !strings.HasPrefix(sampler, "parentbased_") {
+			sampler = "parentbased_" + sampler
+		}
+
+		directives = append(directives, fmt.Sprintf("opentelemetry_config sampler %s;", sampler))
+
+		if cfg.SamplerRatioSet {
+			directives = append(directives, fmt.Sprintf("opentelemetry_config sampler_ratio %v;", cfg.SamplerRatio))
+		}
+	}
+
+	for _, key := range sortedKeys(cfg.SpanAttributes) {
+		directives = append(directives, fmt.Sprintf("opentelemetry_attribute %q %q;", key, cfg.SpanAttributes[key]))
+	}
+
+	for _, key := range sortedKeys(cfg.ResourceAttributes) {
+		directives = append(directives, fmt.Sprintf("opentelemetry_resource_attribute %q %q;", key, cfg.ResourceAttributes[key]))
+	}
+
+	if cfg.OTLPEndpointSet && cfg.OTLPEndpoint != "" {
+		directives = append(directives, fmt.Sprintf("opentelemetry_config otlp_endpoint %q;", cfg.OTLPEndpoint))
+
+		for _, key := range sortedKeys(cfg.OTLPHeaders) {
+			directives = append(directives, fmt.Sprintf("opentelemetry_config otlp_header %q %q;", key, cfg.OTLPHeaders[key]))
+		}
+	}
+
+	if cfg.ServiceName != "" {
+		directives = append(directives, fmt.Sprintf("opentelemetry_resource_attribute \"service.name\" %q;", cfg.ServiceName))
+	}
+
+	if cfg.LogsEnabledSet && cfg.LogsEnabled {
+		severity := cfg.LogsSeverity
+		if severity == "" {
+			severity = "INFO"
+		}
+
+		directives = append(directives, fmt.Sprintf("opentelemetry_config logs_severity %s;", severity))
+
+		// Stamp every exported log record with the current span's identifiers
+		// so backends (Loki, Tempo, an OTel collector) can join access logs
+		// back to the trace that produced them.
+		directives = append(directives, `opentelemetry_config logs_attribute "trace_id" "$opentelemetry_trace_id";`)
+		directives = append(directives, `opentelemetry_config logs_attribute "span_id" "$opentelemetry_span_id";`)
+		directives = append(directives, `opentelemetry_config logs_attribute "trace_flags" "$opentelemetry_trace_flags";`)
+
+		if len(cfg.LogsIncludeHeaders) > 0 {
+			directives = append(directives, fmt.Sprintf("opentelemetry_config logs_include_headers %s;", strings.Join(cfg.LogsIncludeHeaders, " ")))
+		}
+	}
+
+	return strings.Join(directives, "\n")
+}
+
+// sortedKeys returns the keys of m in lexicographic order, so the generated
+// nginx config is stable across reloads regardless of map iteration order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/opentelemetry"
+)
+
+func TestBuildOpentelemetryDisabled(t *testing.T) {
+	assert.Empty(t, buildOpentelemetry(&opentelemetry.Config{Enabled: false}))
+	assert.Empty(t, buildOpentelemetry("not-a-config"))
+	assert.Empty(t, buildOpentelemetry(nil))
+}
+
+func TestBuildOpentelemetryPropagationType(t *testing.T) {
+	got := buildOpentelemetry(&opentelemetry.Config{
+		Enabled:         true,
+		PropagationType: []string{"tracecontext", "baggage", "b3"},
+	})
+
+	assert.Equal(t, `opentelemetry_propagate tracecontext baggage b3;`, got)
+}
+
+func TestBuildOpentelemetrySampler(t *testing.T) {
+	got := buildOpentelemetry(&opentelemetry.Config{
+		Enabled:         true,
+		SamplerSet:      true,
+		Sampler:         "traceidratio",
+		SamplerRatioSet: true,
+		SamplerRatio:    0.25,
+	})
+
+	assert.Equal(t, "opentelemetry_config sampler traceidratio;\nopentelemetry_config sampler_ratio 0.25;", got)
+}
+
+func TestBuildOpentelemetrySamplerParentBased(t *testing.T) {
+	got := buildOpentelemetry(&opentelemetry.Config{
+		Enabled:               true,
+		SamplerSet:            true,
+		Sampler:               "always_on",
+		SamplerParentBasedSet: true,
+		SamplerParentBased:    true,
+		TrustEnabled:          true,
+	})
+
+	assert.Equal(t, "opentelemetry_config sampler parentbased_always_on;", got)
+}
+
+func TestBuildOpentelemetrySamplerParentBasedRequiresTrust(t *testing.T) {
+	got := buildOpentelemetry(&opentelemetry.Config{
+		Enabled:               true,
+		SamplerSet:            true,
+		Sampler:               "always_on",
+		SamplerParentBasedSet: true,
+		SamplerParentBased:    true,
+		TrustEnabled:          false,
+	})
+
+	assert.Equal(t, "opentelemetry_config sampler always_on;", got)
+}
+
+func TestBuildOpentelemetryAttributesAreSorted(t *testing.T) {
+	got := buildOpentelemetry(&opentelemetry.Config{
+		Enabled: true,
+		SpanAttributes: map[string]string{
+			"tenant.id":    "acme",
+			"feature.flag": "beta",
+		},
+		ResourceAttributes: map[string]string{
+			"deployment.environment": "prod",
+		},
+	})
+
+	want := "opentelemetry_attribute \"feature.flag\" \"beta\";\n" +
+		"opentelemetry_attribute \"tenant.id\" \"acme\";\n" +
+		"opentelemetry_resource_attribute \"deployment.environment\" \"prod\";"
+
+	assert.Equal(t, want, got)
+}
+
+func TestBuildOpentelemetryOTLPEndpointAndServiceName(t *testing.T) {
+	got := buildOpentelemetry(&opentelemetry.Config{
+		Enabled:         true,
+		OTLPEndpointSet: true,
+		OTLPEndpoint:    "https://otel.example.com:4318",
+		OTLPHeaders:     map[string]string{"Authorization": "Bearer xyz"},
+		ServiceName:     "checkout",
+	})
+
+	want := "opentelemetry_config otlp_endpoint \"https://otel.example.com:4318\";\n" +
+		"opentelemetry_config otlp_header \"Authorization\" \"Bearer xyz\";\n" +
+		"opentelemetry_resource_attribute \"service.name\" \"checkout\";"
+
+	assert.Equal(t, want, got)
+}
+
+func TestBuildOpentelemetryOTLPEndpointUnsetOmitsHeaders(t *testing.T) {
+	got := buildOpentelemetry(&opentelemetry.Config{
+		Enabled:     true,
+		OTLPHeaders: map[string]string{"Authorization": "Bearer xyz"},
+	})
+
+	assert.Empty(t, got)
+}
+
+func TestBuildOpentelemetryLogsBridge(t *testing.T) {
+	got := buildOpentelemetry(&opentelemetry.Config{
+		Enabled:            true,
+		LogsEnabledSet:     true,
+		LogsEnabled:        true,
+		LogsSeverity:       "WARN",
+		LogsIncludeHeaders: []string{"X-Request-Id", "User-Agent"},
+	})
+
+	want := "opentelemetry_config logs_severity WARN;\n" +
+		`opentelemetry_config logs_attribute "trace_id" "$opentelemetry_trace_id";` + "\n" +
+		`opentelemetry_config logs_attribute "span_id" "$opentelemetry_span_id";` + "\n" +
+		`opentelemetry_config logs_attribute "trace_flags" "$opentelemetry_trace_flags";` + "\n" +
+		"opentelemetry_config logs_include_headers X-Request-Id User-Agent;"
+
+	assert.Equal(t, want, got)
+}
+
+func TestBuildOpentelemetryLogsBridgeDefaultsSeverity(t *testing.T) {
+	got := buildOpentelemetry(&opentelemetry.Config{
+		Enabled:        true,
+		LogsEnabledSet: true,
+		LogsEnabled:    true,
+	})
+
+	want := "opentelemetry_config logs_severity INFO;\n" +
+		`opentelemetry_config logs_attribute "trace_id" "$opentelemetry_trace_id";` + "\n" +
+		`opentelemetry_config logs_attribute "span_id" "$opentelemetry_span_id";` + "\n" +
+		`opentelemetry_config logs_attribute "trace_flags" "$opentelemetry_trace_flags";`
+
+	assert.Equal(t, want, got)
+}
+
+func TestBuildOpentelemetryLogsBridgeCorrelatesTraceAndSpan(t *testing.T) {
+	got := buildOpentelemetry(&opentelemetry.Config{
+		Enabled:        true,
+		LogsEnabledSet: true,
+		LogsEnabled:    true,
+	})
+
+	assert.Contains(t, got, `logs_attribute "trace_id" "$opentelemetry_trace_id";`)
+	assert.Contains(t, got, `logs_attribute "span_id" "$opentelemetry_span_id";`)
+	assert.Contains(t, got, `logs_attribute "trace_flags" "$opentelemetry_trace_flags";`)
+}
+
+func TestBuildOpentelemetryLogsBridgeDisabled(t *testing.T) {
+	got := buildOpentelemetry(&opentelemetry.Config{
+		Enabled:      true,
+		LogsSeverity: "ERROR",
+	})
+
+	assert.Empty(t, got)
+}